@@ -0,0 +1,221 @@
+// Command nextword suggests next English words from stdin, or runs as a
+// long-running server (`nextword serve`) for editor plugins.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+
+	"google.golang.org/grpc"
+
+	"github.com/machari/nextword/internal/server"
+	"github.com/machari/nextword/internal/server/nextwordpb"
+	"github.com/machari/nextword/pkg/nextword"
+)
+
+func main() {
+	var cmd string
+	if len(os.Args) > 1 {
+		cmd = os.Args[1]
+	}
+
+	var err error
+	switch cmd {
+	case "serve":
+		err = runServe(os.Args[2:])
+	case "build-index":
+		err = runBuildIndex(os.Args[2:])
+	case "build-suffixarray":
+		err = runBuildSuffixArray(os.Args[2:])
+	case "build-stats":
+		err = runBuildStats(os.Args[2:])
+	case "compress-data":
+		err = runCompressData(os.Args[2:])
+	default:
+		err = runSuggest(os.Args[1:])
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// newParams builds NextwordParams from the flag set shared by every
+// subcommand.
+func newParams(fs *flag.FlagSet, args []string) (*nextword.NextwordParams, error) {
+	dataPath := fs.String("data-path", os.Getenv("NEXTWORD_DATA_PATH"), "path to the n-gram data directory")
+	candidateNum := fs.Int("candidate-num", 10, "number of candidates to suggest")
+	greedy := fs.Bool("greedy", false, "suggest words from all n-gram data")
+	fuzzy := fs.Bool("fuzzy", false, "fall back to fzf-style fuzzy matching when prefix has no exact dictionary match")
+	indexStride := fs.Int("index-stride", nextword.DefaultIndexStride, "n-gram keys between entries kept resident in each data file's sparse index")
+	ranker := fs.String("ranker", "order", "candidate ranking to use: order, frequency, or kn (kn approximates Kneser-Ney from build-stats' rank-derived counts, not a real corpus)")
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	r, err := parseRanker(*ranker)
+	if err != nil {
+		return nil, err
+	}
+
+	return &nextword.NextwordParams{
+		DataPath:     *dataPath,
+		CandidateNum: *candidateNum,
+		Greedy:       *greedy,
+		Fuzzy:        *fuzzy,
+		IndexStride:  *indexStride,
+		Ranker:       r,
+	}, nil
+}
+
+// parseRanker maps a --ranker flag value to the nextword.Ranker it selects.
+func parseRanker(name string) (nextword.Ranker, error) {
+	switch name {
+	case "", "order":
+		return nextword.OrderRanker{}, nil
+	case "frequency":
+		return nextword.FrequencyRanker{}, nil
+	case "kn":
+		return nextword.KNRanker{}, nil
+	default:
+		return nil, fmt.Errorf("unknown --ranker %q: want order, frequency, or kn", name)
+	}
+}
+
+// runBuildIndex scans an existing data directory and writes a sparse ".idx"
+// file next to every n-gram/dictionary data file, so Suggest/serve can
+// mmap the data and binary-search a narrow byte range instead of the whole
+// file.
+func runBuildIndex(args []string) error {
+	fs := flag.NewFlagSet("nextword build-index", flag.ExitOnError)
+	stride := fs.Int("index-stride", nextword.DefaultIndexStride, "n-gram keys between entries kept resident in each data file's sparse index")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: nextword build-index [--index-stride=N] <datadir>")
+	}
+
+	return nextword.BuildIndex(fs.Arg(0), *stride)
+}
+
+// runBuildSuffixArray scans an existing data directory's dict.txt and
+// writes a gob-serialized suffix array, so SuggestInfix can complete a word
+// by any substring instead of only its prefix.
+func runBuildSuffixArray(args []string) error {
+	fs := flag.NewFlagSet("nextword build-suffixarray", flag.ExitOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: nextword build-suffixarray <datadir>")
+	}
+
+	return nextword.BuildSuffixArray(fs.Arg(0))
+}
+
+// runBuildStats scans an existing data directory and writes the companion
+// ".cnt" counts files KNRanker (--ranker=kn) needs for Kneser-Ney
+// smoothing. Those counts are synthesized from each candidate's existing
+// rank, not measured from a corpus (see nextword.BuildStats) — --ranker=kn
+// is an approximation, not genuine Kneser-Ney.
+func runBuildStats(args []string) error {
+	fs := flag.NewFlagSet("nextword build-stats", flag.ExitOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: nextword build-stats <datadir>")
+	}
+
+	return nextword.BuildStats(fs.Arg(0))
+}
+
+// runCompressData scans an existing data directory and writes a
+// block-compressed ".zst" sibling (plus ".zst.trailer" block index) next to
+// every data file, so Suggest/serve can decompress a single block instead
+// of paying for the whole file.
+func runCompressData(args []string) error {
+	fs := flag.NewFlagSet("nextword compress-data", flag.ExitOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: nextword compress-data <datadir>")
+	}
+
+	return nextword.CompressData(fs.Arg(0))
+}
+
+// runSuggest reads one input per line from stdin and prints the suggested
+// candidates, space-separated.
+func runSuggest(args []string) error {
+	params, err := newParams(flag.NewFlagSet("nextword", flag.ExitOnError), args)
+	if err != nil {
+		return err
+	}
+
+	nw, err := nextword.NewNextword(params)
+	if err != nil {
+		return err
+	}
+	defer nw.Close()
+
+	sc := bufio.NewScanner(os.Stdin)
+	for sc.Scan() {
+		cand, err := nw.Suggest(sc.Text())
+		if err != nil {
+			return err
+		}
+		fmt.Println(strings.Join(cand, " "))
+	}
+	return sc.Err()
+}
+
+// runServe starts the HTTP/JSON and gRPC servers that back `nextword serve`.
+func runServe(args []string) error {
+	fs := flag.NewFlagSet("nextword serve", flag.ExitOnError)
+	httpAddr := fs.String("http-addr", ":8080", "address to serve HTTP/JSON suggestions on")
+	grpcAddr := fs.String("grpc-addr", ":8081", "address to serve gRPC suggestions on")
+
+	params, err := newParams(fs, args)
+	if err != nil {
+		return err
+	}
+
+	nw, err := nextword.NewNextword(params)
+	if err != nil {
+		return err
+	}
+
+	errCh := make(chan error, 2)
+
+	go func() {
+		errCh <- http.ListenAndServe(*httpAddr, server.NewHTTPHandler(nw))
+	}()
+
+	go func() {
+		errCh <- serveGRPC(*grpcAddr, nw)
+	}()
+
+	return <-errCh
+}
+
+func serveGRPC(addr string, nw *nextword.Nextword) error {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	s := grpc.NewServer()
+	nextwordpb.RegisterNextwordServer(s, server.NewGRPCServer(nw))
+	return s.Serve(lis)
+}
@@ -0,0 +1,54 @@
+package server
+
+import (
+	"context"
+	"io"
+
+	"github.com/machari/nextword/internal/server/nextwordpb"
+	"github.com/machari/nextword/pkg/nextword"
+)
+
+// grpcServer adapts nextword.Nextword to the generated NextwordServer
+// interface.
+type grpcServer struct {
+	nextwordpb.UnimplementedNextwordServer
+
+	nw *nextword.Nextword
+}
+
+// NewGRPCServer returns a nextwordpb.NextwordServer backed by nw.
+func NewGRPCServer(nw *nextword.Nextword) nextwordpb.NextwordServer {
+	return &grpcServer{nw: nw}
+}
+
+func (s *grpcServer) Suggest(ctx context.Context, req *nextwordpb.SuggestRequest) (*nextwordpb.SuggestResponse, error) {
+	cand, err := s.nw.Suggest(req.GetInput())
+	if err != nil {
+		return nil, err
+	}
+	return &nextwordpb.SuggestResponse{Candidates: cand}, nil
+}
+
+// StreamSuggest ranks candidates for every input the client sends, so an
+// editor plugin can stream keystrokes and get a fresh candidate list back
+// without reopening the connection.
+func (s *grpcServer) StreamSuggest(stream nextwordpb.Nextword_StreamSuggestServer) error {
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		cand, err := s.nw.Suggest(req.GetInput())
+		if err != nil {
+			return err
+		}
+
+		if err := stream.Send(&nextwordpb.SuggestResponse{Candidates: cand}); err != nil {
+			return err
+		}
+	}
+}
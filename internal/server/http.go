@@ -0,0 +1,75 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/machari/nextword/pkg/nextword"
+)
+
+// suggestResponse is the JSON body returned from /suggest and emitted per
+// event by /suggest/stream.
+type suggestResponse struct {
+	Candidates []string `json:"candidates"`
+}
+
+// NewHTTPHandler returns an http.Handler exposing nw over HTTP/JSON:
+//
+//	GET /suggest?input=...         a single suggestResponse
+//	GET /suggest/stream?input=...  a text/event-stream of suggestResponse,
+//	                                one event per "\n"-separated input line,
+//	                                for editors that want to push partial
+//	                                input as the user types without opening
+//	                                a new connection per keystroke.
+func NewHTTPHandler(nw *nextword.Nextword) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/suggest", handleSuggest(nw))
+	mux.HandleFunc("/suggest/stream", handleSuggestStream(nw))
+	return mux
+}
+
+func handleSuggest(nw *nextword.Nextword) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cand, err := nw.Suggest(r.URL.Query().Get("input"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(suggestResponse{Candidates: cand})
+	}
+}
+
+func handleSuggestStream(nw *nextword.Nextword) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+
+		sc := bufio.NewScanner(r.Body)
+		for sc.Scan() {
+			cand, err := nw.Suggest(sc.Text())
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			body, err := json.Marshal(suggestResponse{Candidates: cand})
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			fmt.Fprintf(w, "data: %s\n\n", body)
+			flusher.Flush()
+		}
+	}
+}
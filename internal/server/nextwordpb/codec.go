@@ -0,0 +1,44 @@
+package nextwordpb
+
+import (
+	"fmt"
+
+	"google.golang.org/grpc/encoding"
+)
+
+// wireMessage is implemented by every nextwordpb message (see
+// nextword.pb.go): each encodes and decodes its own wire bytes instead of
+// relying on google.golang.org/protobuf's reflection, which this package
+// can't satisfy without real protoc-gen-go output.
+type wireMessage interface {
+	Marshal() ([]byte, error)
+	Unmarshal([]byte) error
+}
+
+func init() {
+	// Registering under grpc-go's own codec name ("proto") overrides its
+	// built-in, reflection-based codec for this process, so Suggest and
+	// StreamSuggest actually work instead of panicking on the first real
+	// Marshal call.
+	encoding.RegisterCodec(wireCodec{})
+}
+
+type wireCodec struct{}
+
+func (wireCodec) Name() string { return "proto" }
+
+func (wireCodec) Marshal(v interface{}) ([]byte, error) {
+	m, ok := v.(wireMessage)
+	if !ok {
+		return nil, fmt.Errorf("nextwordpb: cannot marshal %T", v)
+	}
+	return m.Marshal()
+}
+
+func (wireCodec) Unmarshal(data []byte, v interface{}) error {
+	m, ok := v.(wireMessage)
+	if !ok {
+		return fmt.Errorf("nextwordpb: cannot unmarshal into %T", v)
+	}
+	return m.Unmarshal(data)
+}
@@ -0,0 +1,127 @@
+// nextword.pb.go hand-encodes the Suggest/StreamSuggest wire messages.
+//
+// This would normally be `protoc-gen-go` output, but protoc isn't available
+// in every environment this repo builds in. A previous version of this file
+// looked the part (protoimpl.MessageState, ProtoReflect, ...) without the
+// real file-descriptor wiring protoc-gen-go emits alongside it, so
+// proto.Marshal paniced the first time a request actually went over the
+// wire ("invalid nil message info"). Until protoc is available to
+// regenerate this for real, SuggestRequest/SuggestResponse encode their own
+// wire bytes instead of going through google.golang.org/protobuf's
+// reflection-based codec; codec.go registers a grpc codec that calls them.
+// Re-run protoc and delete codec.go once that's possible:
+//
+//	protoc --go_out=. --go_opt=paths=source_relative \
+//	    --go-grpc_out=. --go-grpc_opt=paths=source_relative \
+//	    internal/server/nextwordpb/nextword.proto
+//
+// source: nextword.proto
+package nextwordpb
+
+import "google.golang.org/protobuf/encoding/protowire"
+
+// SuggestRequest is the wire message for Nextword.Suggest/StreamSuggest
+// requests: field 1 is the raw input string.
+type SuggestRequest struct {
+	Input string
+}
+
+func (x *SuggestRequest) GetInput() string {
+	if x != nil {
+		return x.Input
+	}
+	return ""
+}
+
+// Marshal encodes x's fields in proto3 wire format.
+func (x *SuggestRequest) Marshal() ([]byte, error) {
+	var buf []byte
+	if x.Input != "" {
+		buf = protowire.AppendTag(buf, 1, protowire.BytesType)
+		buf = protowire.AppendString(buf, x.Input)
+	}
+	return buf, nil
+}
+
+// Unmarshal decodes buf into x, replacing its current contents.
+func (x *SuggestRequest) Unmarshal(buf []byte) error {
+	*x = SuggestRequest{}
+
+	for len(buf) > 0 {
+		num, typ, n := protowire.ConsumeTag(buf)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		buf = buf[n:]
+
+		if num == 1 && typ == protowire.BytesType {
+			v, n := protowire.ConsumeString(buf)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			x.Input = v
+			buf = buf[n:]
+			continue
+		}
+
+		n = protowire.ConsumeFieldValue(num, typ, buf)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		buf = buf[n:]
+	}
+	return nil
+}
+
+// SuggestResponse is the wire message for Nextword.Suggest/StreamSuggest
+// responses: field 1 is the repeated ranked candidates.
+type SuggestResponse struct {
+	Candidates []string
+}
+
+func (x *SuggestResponse) GetCandidates() []string {
+	if x != nil {
+		return x.Candidates
+	}
+	return nil
+}
+
+// Marshal encodes x's fields in proto3 wire format.
+func (x *SuggestResponse) Marshal() ([]byte, error) {
+	var buf []byte
+	for _, c := range x.Candidates {
+		buf = protowire.AppendTag(buf, 1, protowire.BytesType)
+		buf = protowire.AppendString(buf, c)
+	}
+	return buf, nil
+}
+
+// Unmarshal decodes buf into x, replacing its current contents.
+func (x *SuggestResponse) Unmarshal(buf []byte) error {
+	*x = SuggestResponse{}
+
+	for len(buf) > 0 {
+		num, typ, n := protowire.ConsumeTag(buf)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		buf = buf[n:]
+
+		if num == 1 && typ == protowire.BytesType {
+			v, n := protowire.ConsumeString(buf)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			x.Candidates = append(x.Candidates, v)
+			buf = buf[n:]
+			continue
+		}
+
+		n = protowire.ConsumeFieldValue(num, typ, buf)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		buf = buf[n:]
+	}
+	return nil
+}
@@ -0,0 +1,303 @@
+package nextword
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// CompressedBlockSize is the uncompressed size of each block CompressData
+// splits a data file into before zstd-compressing it, so a candidate block
+// can be decompressed on its own instead of paying for the whole file.
+const CompressedBlockSize = 64 * 1024
+
+// compressedSuffix/trailerSuffix name the files CompressData writes next to
+// an existing data file, e.g. "dict.txt" -> "dict.txt.zst" (the
+// concatenated compressed blocks) and "dict.txt.zst.trailer" (the block
+// index).
+const (
+	compressedSuffix = ".zst"
+	trailerSuffix    = ".trailer"
+)
+
+// compressedBlock is one entry of a ".zst.trailer" file: the first key in
+// the block and where to find the block's bytes in the ".zst" file.
+type compressedBlock struct {
+	firstKey        string
+	fileOffset      int64
+	compressedLen   int64
+	uncompressedLen int64
+}
+
+// CompressData converts every "*.txt" n-gram/dictionary data file in
+// dataPath into a block-compressed sibling: fixed-size (CompressedBlockSize)
+// uncompressed blocks, each its own zstd frame, plus a trailer recording
+// [firstKey, fileOffset, uncompressedLen] (and the compressed length needed
+// to slice the right bytes out) per block. binarySearch's callers bisect
+// the trailer, which is small enough to keep fully resident, to find the
+// one block that can contain a query and decompress only that block. It is
+// the backing implementation of `nextword compress-data`.
+func CompressData(dataPath string) error {
+	matches, err := filepath.Glob(filepath.Join(dataPath, "*.txt"))
+	if err != nil {
+		return err
+	}
+
+	for _, path := range matches {
+		if err := compressFile(path); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+func compressFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	out, err := os.Create(path + compressedSuffix)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	trailerOut, err := os.Create(path + compressedSuffix + trailerSuffix)
+	if err != nil {
+		return err
+	}
+	defer trailerOut.Close()
+	trailerW := bufio.NewWriter(trailerOut)
+
+	var block bytes.Buffer
+	var firstKey string
+	var fileOffset int64
+
+	flush := func() error {
+		if block.Len() == 0 {
+			return nil
+		}
+
+		var compressed bytes.Buffer
+		zw, err := zstd.NewWriter(&compressed)
+		if err != nil {
+			return err
+		}
+		if _, err := zw.Write(block.Bytes()); err != nil {
+			zw.Close()
+			return err
+		}
+		if err := zw.Close(); err != nil {
+			return err
+		}
+
+		if _, err := fmt.Fprintf(trailerW, "%s\t%d\t%d\t%d\n", firstKey, fileOffset, compressed.Len(), block.Len()); err != nil {
+			return err
+		}
+		if _, err := out.Write(compressed.Bytes()); err != nil {
+			return err
+		}
+
+		fileOffset += int64(compressed.Len())
+		block.Reset()
+		firstKey = ""
+		return nil
+	}
+
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, ReadLineBufSize), 1<<20)
+	for sc.Scan() {
+		line := sc.Text()
+		if firstKey == "" {
+			firstKey, _, _ = strings.Cut(line, "\t")
+		}
+		block.WriteString(line)
+		block.WriteByte('\n')
+
+		if block.Len() >= CompressedBlockSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+
+	return trailerW.Flush()
+}
+
+// openCompressed returns fname's trailer, caching it the first time fname
+// is requested. compressed is false when fname has no ".zst" sibling, in
+// which case callers fall back to the mmap-backed plain-file path.
+func (nw *Nextword) openCompressed(fname string) (blocks []compressedBlock, compressed bool, err error) {
+	nw.compressedMu.Lock()
+	defer nw.compressedMu.Unlock()
+
+	if blocks, ok := nw.compressedBlocks[fname]; ok {
+		return blocks, true, nil
+	}
+
+	trailerPath := filepath.Join(nw.params.DataPath, fname+compressedSuffix+trailerSuffix)
+	blocks, err = readTrailer(trailerPath)
+	if err != nil {
+		return nil, false, err
+	}
+	if blocks == nil {
+		return nil, false, nil
+	}
+
+	nw.compressedBlocks[fname] = blocks
+	return blocks, true, nil
+}
+
+// readTrailer reads a ".zst.trailer" file into memory. A missing trailer is
+// not an error: it just means fname has no compressed sibling.
+func readTrailer(path string) ([]compressedBlock, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var blocks []compressedBlock
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		parts := strings.Split(sc.Text(), "\t")
+		if len(parts) != 4 {
+			continue
+		}
+		fileOffset, err1 := strconv.ParseInt(parts[1], 10, 64)
+		compressedLen, err2 := strconv.ParseInt(parts[2], 10, 64)
+		uncompressedLen, err3 := strconv.ParseInt(parts[3], 10, 64)
+		if err1 != nil || err2 != nil || err3 != nil {
+			continue
+		}
+		blocks = append(blocks, compressedBlock{
+			firstKey:        parts[0],
+			fileOffset:      fileOffset,
+			compressedLen:   compressedLen,
+			uncompressedLen: uncompressedLen,
+		})
+	}
+	return blocks, sc.Err()
+}
+
+// decompressBlock reads and decompresses a single block of fname's ".zst"
+// sibling.
+func (nw *Nextword) decompressBlock(fname string, blk compressedBlock) ([]byte, error) {
+	f, err := os.Open(filepath.Join(nw.params.DataPath, fname+compressedSuffix))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	compressed := make([]byte, blk.compressedLen)
+	if _, err := f.ReadAt(compressed, blk.fileOffset); err != nil {
+		return nil, err
+	}
+
+	zr, err := zstd.NewReader(nil)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	return zr.DecodeAll(compressed, make([]byte, 0, blk.uncompressedLen))
+}
+
+// blockIndexFor bisects blocks (sorted by firstKey) for the index of the one
+// block that can contain query.
+func blockIndexFor(blocks []compressedBlock, query string) int {
+	i := sort.Search(len(blocks), func(i int) bool { return blocks[i].firstKey > query }) - 1
+	if i < 0 {
+		i = 0
+	}
+	return i
+}
+
+// blockFor bisects blocks (sorted by firstKey) for the one block that can
+// contain query.
+func blockFor(blocks []compressedBlock, query string) compressedBlock {
+	return blocks[blockIndexFor(blocks, query)]
+}
+
+// searchNgramCompressed is searchNgram's block-compressed path: it
+// decompresses the one block that can hold query and scans it for the
+// matching line.
+func (nw *Nextword) searchNgramCompressed(fname string, blocks []compressedBlock, query string) (candidates []string, err error) {
+	data, err := nw.decompressBlock(fname, blockFor(blocks, query))
+	if err != nil {
+		return nil, err
+	}
+
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		line := sc.Text()
+		if !strings.HasPrefix(line, query) {
+			continue
+		}
+		return strings.Split(strings.Split(line, "\t")[1], " "), nil
+	}
+	return nil, sc.Err()
+}
+
+// searchDictionaryCompressed is searchDictionary's block-compressed path:
+// it decompresses the block that can hold query and collects every line
+// prefixed by it, the same as the plain-file scan would. A run of matches
+// can span a block boundary (CompressedBlockSize doesn't know about prefix
+// groups), so it keeps decompressing subsequent blocks as long as the
+// previous one ended mid-run.
+func (nw *Nextword) searchDictionaryCompressed(blocks []compressedBlock, query string) (candidates []string, err error) {
+	started := false
+
+	for i := blockIndexFor(blocks, query); i < len(blocks); i++ {
+		data, err := nw.decompressBlock("dict.txt", blocks[i])
+		if err != nil {
+			return nil, err
+		}
+
+		matchedInBlock := false
+		sc := bufio.NewScanner(bytes.NewReader(data))
+		for sc.Scan() {
+			line := sc.Text()
+			if !strings.HasPrefix(line, query) {
+				if started {
+					return candidates, nil
+				}
+				continue
+			}
+			started = true
+			matchedInBlock = true
+			candidates = append(candidates, line)
+		}
+		if err := sc.Err(); err != nil {
+			return nil, err
+		}
+
+		// The block ended without a non-matching line after the run
+		// started: the run may continue into the next block. Otherwise
+		// query either isn't present at all (nothing matched) or its run
+		// already ended within this block (handled above).
+		if !matchedInBlock {
+			break
+		}
+	}
+
+	return candidates, nil
+}
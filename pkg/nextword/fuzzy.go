@@ -0,0 +1,157 @@
+package nextword
+
+import (
+	"bufio"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/high-moctane/go-readerer"
+)
+
+// FuzzyWindowBytes bounds how far before and after the binary-searched
+// offset searchDictionaryFuzzy scans, so a miss on a multi-gigabyte
+// dictionary still costs one seek plus a small scan instead of a full pass.
+var FuzzyWindowBytes int64 = 64 * 1024
+
+const (
+	fuzzyConsecutiveBonus = 5
+	fuzzyBoundaryBonus    = 10
+	fuzzyGapPenalty       = 1
+)
+
+// fuzzyMatch reports whether every rune of pattern appears in word in
+// order, and if so an fzf-style score: a bonus for runs of consecutive
+// matched characters, a bonus for matches that start a new word/camelCase
+// segment, and a penalty for the gap since the previous match. span is the
+// distance from the first to the last matched rune, used as a tiebreaker by
+// searchDictionaryFuzzy.
+func fuzzyMatch(pattern, word string) (score, span int, ok bool) {
+	if pattern == "" {
+		return 0, 0, false
+	}
+
+	p := []rune(strings.ToLower(pattern))
+	w := []rune(strings.ToLower(word))
+
+	pi := 0
+	first, prev := -1, -1
+	consecutive := 0
+
+	for wi := 0; wi < len(w) && pi < len(p); wi++ {
+		if w[wi] != p[pi] {
+			continue
+		}
+
+		if prev >= 0 && prev == wi-1 {
+			consecutive++
+			score += fuzzyConsecutiveBonus * consecutive
+		} else {
+			consecutive = 0
+			if wi == 0 || isFuzzyBoundary(w[wi-1]) {
+				score += fuzzyBoundaryBonus
+			}
+			if prev >= 0 {
+				score -= fuzzyGapPenalty * (wi - prev - 1)
+			}
+		}
+
+		if first < 0 {
+			first = wi
+		}
+		prev = wi
+		pi++
+	}
+
+	if pi != len(p) {
+		return 0, 0, false
+	}
+	return score, prev - first + 1, true
+}
+
+// isFuzzyBoundary reports whether r ends a word segment, so the rune after
+// it starts a new one (e.g. the "_" in "word_break" or the end of "camel"
+// in "camelCase").
+func isFuzzyBoundary(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
+
+// fuzzyCandidate is a dict.txt entry scored against a prefix that had no
+// exact match.
+type fuzzyCandidate struct {
+	word  string
+	score int
+	span  int
+}
+
+// searchDictionaryFuzzy ranks dict.txt entries near prefix's binary-searched
+// position by fzf-style subsequence score. It is the fallback Suggest uses
+// when searchDictionary finds no exact prefix match and NextwordParams.Fuzzy
+// is set. It only scans a bounded window around that offset
+// (FuzzyWindowBytes in each direction) instead of the whole file, so a miss
+// stays cheap even on a multi-gigabyte dictionary.
+func (nw *Nextword) searchDictionaryFuzzy(prefix string) (candidates []string, err error) {
+	idx, err := nw.openIndex("dict.txt")
+	if err != nil {
+		return nil, err
+	}
+
+	left, right := idx.bound(prefix)
+	offset, err := nw.binarySearch(idx.reader, left, right, prefix)
+	if err != nil {
+		if err = nw.removeEOF(err); err != nil {
+			return nil, err
+		}
+	}
+
+	start := offset - FuzzyWindowBytes
+	if start < 0 {
+		start = 0
+	}
+	end := offset + FuzzyWindowBytes
+	if end > idx.size {
+		end = idx.size
+	}
+
+	r := readerer.FromReaderAt(idx.reader, start)
+	sc := bufio.NewScanner(r)
+
+	var matches []fuzzyCandidate
+	for pos := start; pos < end && sc.Scan(); {
+		line := sc.Text()
+		pos += int64(len(line)) + 1 // "\n"
+
+		word, _, ok := strings.Cut(line, "\t")
+		if !ok {
+			continue
+		}
+
+		score, span, ok := fuzzyMatch(prefix, word)
+		if !ok {
+			continue
+		}
+		matches = append(matches, fuzzyCandidate{word: word, score: score, span: span})
+	}
+	if sc.Err() != nil {
+		return nil, sc.Err()
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if a.score != b.score {
+			return a.score > b.score
+		}
+		if a.span != b.span {
+			return a.span < b.span
+		}
+		return len(a.word) < len(b.word)
+	})
+
+	for _, m := range matches {
+		if len(candidates) >= nw.params.CandidateNum {
+			break
+		}
+		candidates = append(candidates, m.word)
+	}
+	return candidates, nil
+}
@@ -0,0 +1,189 @@
+package nextword
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/exp/mmap"
+)
+
+// DefaultIndexStride is the number of n-gram keys between entries kept
+// resident in a data file's sparse index, used when NextwordParams.IndexStride
+// is unset.
+const DefaultIndexStride = 128
+
+// idxSuffix names the sparse index file that sits next to each n-gram or
+// dictionary data file, e.g. "dict.txt" -> "dict.txt.idx".
+const idxSuffix = ".idx"
+
+// ngramIndex is a data file mmapped once and kept open for the lifetime of
+// a Nextword, plus the sparse, fully resident key -> offset map produced by
+// `nextword build-index` that narrows a binarySearch to a small byte range
+// before it has to touch the mmap.
+type ngramIndex struct {
+	reader *mmap.ReaderAt
+	size   int64
+
+	keys    []string // sorted, every IndexStride-th key in the file
+	offsets []int64  // offsets[i] is the byte offset at which keys[i] starts
+}
+
+// bound returns the smallest byte range of the data file guaranteed to
+// contain query, using the sparse index. If no ".idx" file was found, it
+// falls back to the whole file, which is exactly the old per-call behaviour.
+func (idx *ngramIndex) bound(query string) (left, right int64) {
+	if len(idx.keys) == 0 {
+		return 0, idx.size
+	}
+
+	i := sort.Search(len(idx.keys), func(i int) bool { return idx.keys[i] > query })
+
+	left = 0
+	if i > 0 {
+		// idx.offsets[i-1] is itself the start of a line. binarySearch only
+		// snaps a probe forward to the next line boundary, so handing it a
+		// left bound that already sits exactly on one makes it skip straight
+		// past that line (the same way it only treats offset 0 as "already
+		// at a line start"). Back up one byte, into the previous line, so
+		// the indexed key stays reachable.
+		left = idx.offsets[i-1] - 1
+		if left < 0 {
+			left = 0
+		}
+	}
+	right = idx.size
+	if i < len(idx.offsets) {
+		right = idx.offsets[i]
+	}
+	return
+}
+
+// Close unmaps the underlying data file.
+func (idx *ngramIndex) Close() error {
+	return idx.reader.Close()
+}
+
+// openIndex returns the cached *ngramIndex for fname, mmap-opening the data
+// file and loading its sparse ".idx" sibling the first time fname is
+// requested. Later calls reuse the same mmap handle instead of paying
+// os.Open/os.Stat on every Suggest.
+func (nw *Nextword) openIndex(fname string) (*ngramIndex, error) {
+	nw.indexMu.Lock()
+	defer nw.indexMu.Unlock()
+
+	if idx, ok := nw.indexes[fname]; ok {
+		return idx, nil
+	}
+
+	path := filepath.Join(nw.params.DataPath, fname)
+	reader, err := mmap.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	keys, offsets, err := readSparseIndex(path + idxSuffix)
+	if err != nil {
+		reader.Close()
+		return nil, err
+	}
+
+	idx := &ngramIndex{
+		reader:  reader,
+		size:    int64(reader.Len()),
+		keys:    keys,
+		offsets: offsets,
+	}
+	nw.indexes[fname] = idx
+	return idx, nil
+}
+
+// readSparseIndex reads a "key\toffset\n" sparse index written by
+// BuildIndex. A missing index file is not an error: it just means the
+// caller falls back to binary-searching the whole data file.
+func readSparseIndex(idxPath string) (keys []string, offsets []int64, err error) {
+	f, err := os.Open(idxPath)
+	if os.IsNotExist(err) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		key, offsetStr, ok := strings.Cut(sc.Text(), "\t")
+		if !ok {
+			continue
+		}
+		offset, err := strconv.ParseInt(offsetStr, 10, 64)
+		if err != nil {
+			continue
+		}
+		keys = append(keys, key)
+		offsets = append(offsets, offset)
+	}
+	return keys, offsets, sc.Err()
+}
+
+// BuildIndex scans every "*.txt" n-gram/dictionary data file in dataPath and
+// writes a sparse ".idx" sibling mapping every stride-th key to its byte
+// offset. It is the backing implementation of `nextword build-index` and
+// must be re-run whenever the data files change.
+func BuildIndex(dataPath string, stride int) error {
+	if stride <= 0 {
+		stride = DefaultIndexStride
+	}
+
+	matches, err := filepath.Glob(filepath.Join(dataPath, "*.txt"))
+	if err != nil {
+		return err
+	}
+
+	for _, path := range matches {
+		if err := buildFileIndex(path, stride); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func buildFileIndex(path string, stride int) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	out, err := os.Create(path + idxSuffix)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	w := bufio.NewWriter(out)
+
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, ReadLineBufSize), 1<<20)
+
+	var offset int64
+	for n := 0; sc.Scan(); n++ {
+		line := sc.Text()
+		if n%stride == 0 {
+			key, _, _ := strings.Cut(line, "\t")
+			if _, err := w.WriteString(key + "\t" + strconv.FormatInt(offset, 10) + "\n"); err != nil {
+				return err
+			}
+		}
+		offset += int64(len(line)) + 1 // "\n"
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+
+	return w.Flush()
+}
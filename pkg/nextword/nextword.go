@@ -1,13 +1,19 @@
-package main
+// Package nextword implements the core next-word suggestion engine: binary
+// search over sorted n-gram/dictionary data files and simple candidate
+// merging. It is imported by cmd/nextword for both the one-shot CLI and the
+// long-running server mode.
+package nextword
 
 import (
 	"bufio"
 	"errors"
 	"fmt"
+	"index/suffixarray"
 	"io"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 
 	"github.com/high-moctane/go-readerer"
 )
@@ -20,12 +26,38 @@ type NextwordParams struct {
 	DataPath     string
 	CandidateNum int  // Number of candidates
 	Greedy       bool // If true, Nextword suggests words from all n-gram data.
+
+	// Fuzzy enables fzf-style fuzzy matching in searchDictionary's fallback
+	// when prefix has no exact match, instead of returning no candidates.
+	Fuzzy bool
+
+	// IndexStride is the spacing, in n-gram keys, between entries kept
+	// resident in a data file's sparse ".idx" index. Smaller values use
+	// more memory but narrow the byte range binarySearch has to touch
+	// on disk. Zero means DefaultIndexStride.
+	IndexStride int
+
+	// Ranker orders the candidates Suggest returns. OrderRanker (file
+	// order, deduplicated) is used when this is nil.
+	Ranker Ranker
 }
 
 // Nextword suggests next English words.
 type Nextword struct {
 	params          *NextwordParams
 	readLineBufSize int
+	indexStride     int
+
+	indexMu sync.Mutex
+	indexes map[string]*ngramIndex // fname -> mmap-backed index, opened lazily and cached
+
+	suffixArrayOnce sync.Once
+	suffixArraySA   *suffixarray.Index
+	suffixArrayIdx  *suffixArrayIndex
+	suffixArrayErr  error
+
+	compressedMu     sync.Mutex
+	compressedBlocks map[string][]compressedBlock // fname -> trailer, cached once loaded
 }
 
 // NewNextword returns new Nextword. If params is not valid, err will be not nil.
@@ -49,12 +81,35 @@ func NewNextword(params *NextwordParams) (*Nextword, error) {
 		return nil, errors.New("candidate-num must be a positive integer")
 	}
 
+	indexStride := params.IndexStride
+	if indexStride <= 0 {
+		indexStride = DefaultIndexStride
+	}
+
 	return &Nextword{
-		params:          params,
-		readLineBufSize: ReadLineBufSize,
+		params:           params,
+		readLineBufSize:  ReadLineBufSize,
+		indexStride:      indexStride,
+		indexes:          map[string]*ngramIndex{},
+		compressedBlocks: map[string][]compressedBlock{},
 	}, nil
 }
 
+// Close releases the mmap handles opened for n-gram/dictionary data files.
+func (nw *Nextword) Close() error {
+	nw.indexMu.Lock()
+	defer nw.indexMu.Unlock()
+
+	var err error
+	for fname, idx := range nw.indexes {
+		if cerr := idx.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+		delete(nw.indexes, fname)
+	}
+	return err
+}
+
 // Suggest suggests next English words from input. If input ends with " ",
 // it returns all likely words. If not, it returns the words that begins the last
 // word of input.
@@ -76,10 +131,8 @@ func (nw *Nextword) Suggest(input string) (candidates []string, err error) {
 		candidates = nw.mergeCandidates(candidates, cand)
 
 		// end condition
-		if len(candidates) > nw.params.CandidateNum {
-			candidates = candidates[:nw.params.CandidateNum]
-		}
 		if !nw.params.Greedy && len(candidates) > 0 {
+			candidates, err = nw.rank(ngram, candidates)
 			return
 		}
 	}
@@ -87,16 +140,37 @@ func (nw *Nextword) Suggest(input string) (candidates []string, err error) {
 	// search 1-gram
 	// cand, err := nw.searchOneGram(prefix)
 	cand, err := nw.searchDictionary(prefix)
-
 	if err != nil {
 		return
 	}
+	if len(cand) == 0 && nw.params.Fuzzy && prefix != "" {
+		cand, err = nw.searchDictionaryFuzzy(prefix)
+		if err != nil {
+			return
+		}
+	}
 	candidates = nw.mergeCandidates(candidates, cand)
-	if len(candidates) > nw.params.CandidateNum {
-		candidates = candidates[:nw.params.CandidateNum]
+	candidates, err = nw.rank(ngram, candidates)
+	return
+}
+
+// rank reorders candidates best-first using nw.params.Ranker (OrderRanker if
+// unset) and truncates the result to CandidateNum.
+func (nw *Nextword) rank(ngram, candidates []string) ([]string, error) {
+	ranker := nw.params.Ranker
+	if ranker == nil {
+		ranker = OrderRanker{}
 	}
 
-	return
+	ranked, err := ranker.Rank(nw, ngram, candidates)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ranked) > nw.params.CandidateNum {
+		ranked = ranked[:nw.params.CandidateNum]
+	}
+	return ranked, nil
 }
 
 // parseInput returns last ngram and prefix in the input.
@@ -130,30 +204,37 @@ func (nw *Nextword) searchNgram(ngram []string) (candidates []string, err error)
 		return
 	}
 
+	query := strings.Join(ngram, " ") + "\t"
+
+	// prefer a block-compressed sibling, if `nextword compress-data` has
+	// produced one for fname
+	blocks, compressed, err := nw.openCompressed(fname)
+	if err != nil {
+		return
+	}
+	if compressed {
+		return nw.searchNgramCompressed(fname, blocks, query)
+	}
+
 	// open
 	path := filepath.Join(nw.params.DataPath, fname)
 	if _, err = os.Stat(path); err != nil {
 		return nil, nil
 	}
-	f, err := os.Open(path)
-	if err != nil {
-		return
-	}
-	defer f.Close()
-	fi, err := os.Stat(path)
+	idx, err := nw.openIndex(fname)
 	if err != nil {
 		return
 	}
 
 	// search
-	query := strings.Join(ngram, " ") + "\t"
-	offset, err := nw.binarySearch(f, 0, fi.Size(), query)
+	left, right := idx.bound(query)
+	offset, err := nw.binarySearch(idx.reader, left, right, query)
 	if err != nil {
 		err = nw.removeEOF(err)
 		return
 	}
 
-	line, err := nw.readLine(f, offset)
+	line, err := nw.readLine(idx.reader, offset)
 	if err != nil {
 		err = nw.removeEOF(err)
 		return
@@ -229,22 +310,27 @@ func (nw *Nextword) searchDictionary(prefix string) (candidates []string, err er
 		return
 	}
 
-	// open
-	path := filepath.Join(nw.params.DataPath, "dict.txt")
-	f, err := os.Open(path)
+	query := prefix + "\t"
+
+	// prefer a block-compressed sibling, if `nextword compress-data` has
+	// produced one for dict.txt
+	blocks, compressed, err := nw.openCompressed("dict.txt")
 	if err != nil {
 		return
 	}
-	defer f.Close()
-	fi, err := os.Stat(path)
+	if compressed {
+		return nw.searchDictionaryCompressed(blocks, query)
+	}
+
+	// open
+	idx, err := nw.openIndex("dict.txt")
 	if err != nil {
 		return
 	}
 
-	query := prefix + "\t"
-
 	// search offset
-	offset, err := nw.binarySearch(f, 0, fi.Size(), query)
+	left, right := idx.bound(query)
+	offset, err := nw.binarySearch(idx.reader, left, right, query)
 	if err != nil {
 		if err == io.EOF {
 			err = nil
@@ -253,7 +339,7 @@ func (nw *Nextword) searchDictionary(prefix string) (candidates []string, err er
 	}
 
 	// collect
-	r := readerer.FromReaderAt(f, offset)
+	r := readerer.FromReaderAt(idx.reader, offset)
 	sc := bufio.NewScanner(r)
 	for sc.Scan() {
 		line := sc.Text()
@@ -286,16 +372,16 @@ func (nw *Nextword) binarySearch(r io.ReaderAt, left, right int64, query string)
 			offset = mid + int64(len(str)) + 1 // "\n"
 		}
 
-		var line string
-		line, err = nw.readLine(r, offset)
+		var cmp int
+		cmp, err = nw.matchPrefixCmp(r, offset, query)
 		if err != nil {
 			err = nw.removeEOF(err)
 			return
 		}
 
-		if query < line {
+		if cmp < 0 {
 			right = mid - 1
-		} else if query == line {
+		} else if cmp == 0 {
 			return
 		} else {
 			left = mid + 1
@@ -318,6 +404,42 @@ func (nw *Nextword) binarySearch(r io.ReaderAt, left, right int64, query string)
 	return
 }
 
+// matchPrefixCmp three-way compares query against the line starting at
+// offset, the same as comparing the two full strings would (a line that is
+// a strict prefix of the other sorts first), but it only reads up to
+// len(query)+1 bytes and stops at the first differing byte. That keeps
+// binarySearch from allocating a full line string per probe, which matters
+// once the n-gram key is a few bytes and everything after the "\t" is a
+// long candidate list.
+func (nw *Nextword) matchPrefixCmp(r io.ReaderAt, offset int64, query string) (cmp int, err error) {
+	buf := make([]byte, len(query)+1)
+	n, err := r.ReadAt(buf, offset)
+	if err != nil && err != io.EOF {
+		return 0, err
+	}
+	buf = buf[:n]
+	err = nil
+
+	for i := 0; i < len(query); i++ {
+		if i >= len(buf) || buf[i] == '\n' {
+			// line ended before query did: line < query
+			return 1, nil
+		}
+		if query[i] != buf[i] {
+			if query[i] < buf[i] {
+				return -1, nil
+			}
+			return 1, nil
+		}
+	}
+
+	if len(buf) > len(query) && buf[len(query)] != '\n' {
+		// query matched but the line keeps going: query < line
+		return -1, nil
+	}
+	return 0, nil
+}
+
 // readLine reads r from offset until "\n".
 func (nw *Nextword) readLine(r io.ReaderAt, offset int64) (string, error) {
 	strBuilder := new(strings.Builder)
@@ -0,0 +1,122 @@
+package nextword
+
+import "sort"
+
+// Ranker orders the candidates Suggest found for an input, best first.
+// ngram is the full context Suggest parsed from that input (up to the last
+// four words), regardless of which order actually produced a given
+// candidate.
+type Ranker interface {
+	Rank(nw *Nextword, ngram, candidates []string) ([]string, error)
+}
+
+// OrderRanker is the original behaviour: candidates are left in the order
+// mergeCandidates found them (longest-context matches first, file order
+// within a context). It is used when NextwordParams.Ranker is nil.
+type OrderRanker struct{}
+
+// Rank returns candidates unchanged.
+func (OrderRanker) Rank(nw *Nextword, ngram, candidates []string) ([]string, error) {
+	return candidates, nil
+}
+
+// FrequencyRanker orders candidates by their raw dict.txt frequency,
+// highest first, regardless of which n-gram order matched them.
+type FrequencyRanker struct{}
+
+// Rank sorts candidates by dict.txt frequency, descending.
+func (FrequencyRanker) Rank(nw *Nextword, ngram, candidates []string) ([]string, error) {
+	freqs := make(map[string]int64, len(candidates))
+	for _, w := range candidates {
+		f, err := nw.wordFrequency(w)
+		if err != nil {
+			return nil, err
+		}
+		freqs[w] = f
+	}
+
+	ranked := append([]string(nil), candidates...)
+	sort.SliceStable(ranked, func(i, j int) bool { return freqs[ranked[i]] > freqs[ranked[j]] })
+	return ranked, nil
+}
+
+// KNRanker orders candidates by an interpolated Kneser-Ney score computed
+// across the same 4/3/2/1-gram backoffs Suggest queries, using the counts
+// `nextword build-stats` writes next to each data file. Higher-order counts
+// are weighted more, but a context with few or no counts falls back
+// smoothly to a shorter one instead of contributing nothing.
+//
+// The counts build-stats writes are synthesized from each candidate's rank
+// rather than measured from a corpus (see BuildStats), so this is an
+// approximation of Kneser-Ney, not the genuine article.
+type KNRanker struct{}
+
+// Rank sorts candidates by interpolated Kneser-Ney score, descending.
+func (KNRanker) Rank(nw *Nextword, ngram, candidates []string) ([]string, error) {
+	scores, err := nw.knScores(ngram, candidates)
+	if err != nil {
+		return nil, err
+	}
+
+	ranked := append([]string(nil), candidates...)
+	sort.SliceStable(ranked, func(i, j int) bool { return scores[ranked[i]] > scores[ranked[j]] })
+	return ranked, nil
+}
+
+// knScores computes P_KN(w|ngram) for every w in candidates:
+//
+//	P_KN(w|context) = max(c(context,w)-D,0)/c(context) + λ(context)·P_KN(w|shorter_context)
+//
+// with D = kneserNeyDiscount and λ(context) = D·(distinct continuations of
+// context)/c(context). It works bottom-up from a unigram frequency base
+// case out to ngram's full length, reusing each order's counts across all
+// candidates instead of refetching them per word.
+func (nw *Nextword) knScores(ngram, candidates []string) (map[string]float64, error) {
+	scores := make(map[string]float64, len(candidates))
+	var totalFreq int64
+	for _, w := range candidates {
+		freq, err := nw.wordFrequency(w)
+		if err != nil {
+			return nil, err
+		}
+		scores[w] = float64(freq)
+		totalFreq += freq
+	}
+	// Normalize to a unigram probability: the discounted terms computed
+	// below are on a [0,1) scale, and so must this base case be, or
+	// lambda*scores[w] swamps them and the final order collapses to a
+	// raw-frequency sort.
+	if totalFreq > 0 {
+		for w := range scores {
+			scores[w] /= float64(totalFreq)
+		}
+	}
+
+	for k := 1; k <= len(ngram); k++ {
+		ctx := ngram[len(ngram)-k:]
+
+		words, counts, total, err := nw.ngramCounts(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if total == 0 {
+			continue
+		}
+
+		countOf := make(map[string]int64, len(words))
+		for i, w := range words {
+			countOf[w] = counts[i]
+		}
+		lambda := kneserNeyDiscount * float64(len(words)) / float64(total)
+
+		for _, w := range candidates {
+			discounted := 0.0
+			if d := float64(countOf[w]) - kneserNeyDiscount; d > 0 {
+				discounted = d / float64(total)
+			}
+			scores[w] = discounted + lambda*scores[w]
+		}
+	}
+
+	return scores, nil
+}
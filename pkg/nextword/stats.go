@@ -0,0 +1,181 @@
+package nextword
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// kneserNeyDiscount is the absolute discount D subtracted from every
+// non-zero n-gram count in the interpolated Kneser-Ney formula.
+const kneserNeyDiscount = 0.75
+
+// cntFileName returns the companion counts file BuildStats writes next to
+// an n-gram data file, e.g. "4gram-a.txt" -> "4gram-a.cnt".
+func cntFileName(fname string) string {
+	return strings.TrimSuffix(fname, filepath.Ext(fname)) + ".cnt"
+}
+
+// BuildStats scans every Ngram-x.txt file in dataPath and writes a
+// companion Ngram-x.cnt file that KNRanker uses for interpolated
+// Kneser-Ney smoothing.
+//
+// IMPORTANT CAVEAT: these are not real continuation counts. The shipped
+// data files only record candidates in descending frequency order, never
+// the raw corpus counts Kneser-Ney is defined over, so BuildStats
+// synthesizes a monotonically decreasing count per candidate from its rank
+// in that order instead: the i-th of n candidates gets count n-i. That
+// reproduces the *shape* KN smoothing wants (higher-ranked words pull more
+// weight, lightly-attested contexts back off more readily), but c(context,
+// w), c(context) and the λ continuation term are all derived from the
+// existing order rather than measured, so KNRanker is best read as an
+// order-aware reshuffling of that rank, not a faithful KN model. Treat it
+// as an approximation until genuine per-ngram counts are available. It is
+// the backing implementation of `nextword build-stats` and must be re-run
+// whenever the data files change.
+func BuildStats(dataPath string) error {
+	matches, err := filepath.Glob(filepath.Join(dataPath, "[1-4]gram-*.txt"))
+	if err != nil {
+		return err
+	}
+
+	for _, path := range matches {
+		if err := buildFileStats(path); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+func buildFileStats(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	out, err := os.Create(cntFileName(path))
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	w := bufio.NewWriter(out)
+
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, ReadLineBufSize), 1<<20)
+	for sc.Scan() {
+		ctx, rest, ok := strings.Cut(sc.Text(), "\t")
+		if !ok {
+			continue
+		}
+		words := strings.Split(rest, " ")
+
+		counts := make([]string, len(words))
+		for i := range words {
+			counts[i] = strconv.Itoa(len(words) - i)
+		}
+
+		if _, err := fmt.Fprintf(w, "%s\t%s\n", ctx, strings.Join(counts, " ")); err != nil {
+			return err
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+
+	return w.Flush()
+}
+
+// ngramCounts looks up ngram's companion counts line, returning the
+// candidate words searchNgram would return for the same context, their
+// synthesized counts in the same order, and the counts' sum (c(context) in
+// the Kneser-Ney formula). A missing .cnt file, or a context absent from
+// it, both yield a nil/zero result rather than an error: KNRanker treats
+// that as "back off further".
+func (nw *Nextword) ngramCounts(ngram []string) (words []string, counts []int64, total int64, err error) {
+	words, err = nw.searchNgram(ngram)
+	if err != nil || len(words) == 0 {
+		return nil, nil, 0, err
+	}
+
+	fname, ok := nw.ngramFileName(ngram)
+	if !ok {
+		return nil, nil, 0, nil
+	}
+	cntFname := cntFileName(fname)
+	if _, err := os.Stat(filepath.Join(nw.params.DataPath, cntFname)); err != nil {
+		return nil, nil, 0, nil
+	}
+
+	idx, err := nw.openIndex(cntFname)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	query := strings.Join(ngram, " ") + "\t"
+	left, right := idx.bound(query)
+	offset, err := nw.binarySearch(idx.reader, left, right, query)
+	if err != nil {
+		return nil, nil, 0, nw.removeEOF(err)
+	}
+
+	line, err := nw.readLine(idx.reader, offset)
+	if err != nil {
+		return nil, nil, 0, nw.removeEOF(err)
+	}
+	if !strings.HasPrefix(line, query) {
+		return nil, nil, 0, nil
+	}
+
+	countStrs := strings.Split(strings.TrimPrefix(line, query), " ")
+	n := len(words)
+	if len(countStrs) < n {
+		n = len(countStrs)
+	}
+
+	counts = make([]int64, n)
+	for i := 0; i < n; i++ {
+		c, perr := strconv.ParseInt(countStrs[i], 10, 64)
+		if perr != nil {
+			return nil, nil, 0, nil
+		}
+		counts[i] = c
+		total += c
+	}
+
+	return words[:n], counts, total, nil
+}
+
+// wordFrequency returns w's raw frequency from dict.txt ("word\tfreq" per
+// line), or 0 if w is absent or dict.txt carries no frequency field.
+func (nw *Nextword) wordFrequency(w string) (int64, error) {
+	idx, err := nw.openIndex("dict.txt")
+	if err != nil {
+		return 0, err
+	}
+
+	query := w + "\t"
+	left, right := idx.bound(query)
+	offset, err := nw.binarySearch(idx.reader, left, right, query)
+	if err != nil {
+		return 0, nw.removeEOF(err)
+	}
+
+	line, err := nw.readLine(idx.reader, offset)
+	if err != nil {
+		return 0, nw.removeEOF(err)
+	}
+	if !strings.HasPrefix(line, query) {
+		return 0, nil
+	}
+
+	freq, err := strconv.ParseInt(strings.TrimPrefix(line, query), 10, 64)
+	if err != nil {
+		return 0, nil
+	}
+	return freq, nil
+}
@@ -0,0 +1,149 @@
+package nextword
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/gob"
+	"index/suffixarray"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// suffixArrayFileName is where BuildSuffixArray writes its serialized
+// index, and where Nextword.SuggestInfix loads it from on first use.
+const suffixArrayFileName = "dict.sa.gob"
+
+// suffixArrayIndex is the gob-serialized representation of a 1-gram
+// dictionary's suffix array: the serialized index/suffixarray.Index (which
+// carries the concatenated word blob along with it) plus the byte offset,
+// text and frequency of each word it was built from.
+type suffixArrayIndex struct {
+	SA     []byte // index/suffixarray.Index.Write output
+	Starts []int  // Starts[i] is the offset in the blob where Words[i] begins
+	Words  []string
+	Freqs  []int64
+}
+
+// BuildSuffixArray scans dataPath's dict.txt ("word\tfreq" per line) and
+// writes a gob-serialized suffix array to suffixArrayFileName, so
+// Nextword.SuggestInfix can complete a word by any substring instead of
+// only a prefix. It is the backing implementation of `nextword
+// build-suffixarray` and must be re-run whenever dict.txt changes.
+func BuildSuffixArray(dataPath string) error {
+	f, err := os.Open(filepath.Join(dataPath, "dict.txt"))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var blob bytes.Buffer
+	idx := &suffixArrayIndex{}
+
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, ReadLineBufSize), 1<<20)
+	for sc.Scan() {
+		word, freqStr, _ := strings.Cut(sc.Text(), "\t")
+		if word == "" {
+			continue
+		}
+		freq, _ := strconv.ParseInt(freqStr, 10, 64)
+
+		idx.Starts = append(idx.Starts, blob.Len())
+		idx.Words = append(idx.Words, word)
+		idx.Freqs = append(idx.Freqs, freq)
+		blob.WriteString(word)
+		blob.WriteByte('\n')
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+
+	var saBuf bytes.Buffer
+	if err := suffixarray.New(blob.Bytes()).Write(&saBuf); err != nil {
+		return err
+	}
+	idx.SA = saBuf.Bytes()
+
+	out, err := os.Create(filepath.Join(dataPath, suffixArrayFileName))
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	return gob.NewEncoder(out).Encode(idx)
+}
+
+// loadSuffixArray lazily reads and decodes suffixArrayFileName the first
+// time SuggestInfix is called, and caches the result for the lifetime of
+// nw.
+func (nw *Nextword) loadSuffixArray() (*suffixarray.Index, *suffixArrayIndex, error) {
+	nw.suffixArrayOnce.Do(func() {
+		f, err := os.Open(filepath.Join(nw.params.DataPath, suffixArrayFileName))
+		if err != nil {
+			nw.suffixArrayErr = err
+			return
+		}
+		defer f.Close()
+
+		idx := &suffixArrayIndex{}
+		if err := gob.NewDecoder(f).Decode(idx); err != nil {
+			nw.suffixArrayErr = err
+			return
+		}
+
+		sa := new(suffixarray.Index)
+		if err := sa.Read(bytes.NewReader(idx.SA)); err != nil {
+			nw.suffixArrayErr = err
+			return
+		}
+
+		nw.suffixArrayIdx = idx
+		nw.suffixArraySA = sa
+	})
+
+	return nw.suffixArraySA, nw.suffixArrayIdx, nw.suffixArrayErr
+}
+
+// SuggestInfix completes substr against any part of a word, not just its
+// prefix (e.g. "sphere" suggests "atmosphere", "hemisphere"), ranked by
+// 1-gram frequency. It requires `nextword build-suffixarray` to have been
+// run against the data directory, and loads the resulting index lazily on
+// first use.
+func (nw *Nextword) SuggestInfix(substr string) (candidates []string, err error) {
+	if substr == "" {
+		return nil, nil
+	}
+
+	sa, idx, err := nw.loadSuffixArray()
+	if err != nil {
+		return nil, err
+	}
+
+	offsets := sa.Lookup([]byte(substr), -1)
+
+	seen := make(map[int]bool, len(offsets))
+	var words []int // indices into idx.Words, one per distinct match
+	for _, off := range offsets {
+		wi := sort.Search(len(idx.Starts), func(i int) bool { return idx.Starts[i] > off }) - 1
+		if wi < 0 || seen[wi] {
+			continue
+		}
+		seen[wi] = true
+		words = append(words, wi)
+	}
+
+	sort.SliceStable(words, func(i, j int) bool {
+		return idx.Freqs[words[i]] > idx.Freqs[words[j]]
+	})
+
+	for _, wi := range words {
+		if len(candidates) >= nw.params.CandidateNum {
+			break
+		}
+		candidates = append(candidates, idx.Words[wi])
+	}
+	return candidates, nil
+}